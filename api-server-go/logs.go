@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// logEntry is the shape pushed to logs_subscription notifications,
+// mirroring the fields BuildServer.publishLog writes into the stream.
+type logEntry struct {
+	ID     string `json:"id"`
+	Log    string `json:"log"`
+	Stream string `json:"stream"`
+	Ts     string `json:"ts"`
+}
+
+// subscribeLogs replays a project's log stream from `from` ("0" for the
+// full history, "$" for live-only, or a specific stream id to resume
+// after), then keeps tailing it live. If the Redis server doesn't
+// support Streams (old version, or the key predates this feature and is
+// a plain string), it falls back to the legacy PUBLISH/PSUBSCRIBE path
+// with no replay.
+func (wsc *wsConnection) subscribeLogs(project, from string) string {
+	if from == "" {
+		from = "$"
+	}
+	streamKey := "logs:" + project
+
+	subID, ctx := wsc.registerSub()
+
+	go func() {
+		lastID := from
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			res, err := wsc.api.subscriber.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{streamKey, lastID},
+				Block:   5 * time.Second,
+				Count:   100,
+			}).Result()
+
+			if err == redis.Nil {
+				continue // block timed out, nothing new yet
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if isStreamsUnsupported(err) {
+					log.Printf("Redis Streams unavailable (%v), falling back to pubsub for %s", err, streamKey)
+					wsc.tailViaPubsub(ctx, subID, streamKey)
+					return
+				}
+				log.Printf("XREAD on %s failed: %v", streamKey, err)
+				time.Sleep(time.Second)
+				continue
+			}
+
+			for _, stream := range res {
+				for _, msg := range stream.Messages {
+					wsc.notify(subID, "logs_subscription", toLogEntry(msg))
+					lastID = msg.ID
+				}
+			}
+		}
+	}()
+
+	return subID
+}
+
+func toLogEntry(msg redis.XMessage) logEntry {
+	entry := logEntry{ID: msg.ID}
+	if v, ok := msg.Values["log"].(string); ok {
+		entry.Log = v
+	}
+	if v, ok := msg.Values["stream"].(string); ok {
+		entry.Stream = v
+	}
+	if v, ok := msg.Values["ts"].(string); ok {
+		entry.Ts = v
+	}
+	return entry
+}
+
+// isStreamsUnsupported reports whether err indicates the target Redis
+// deployment can't do XREAD (old server, or logs:<project> already
+// exists as a non-stream key from a prior pubsub-only deployment).
+func isStreamsUnsupported(err error) bool {
+	msg := strings.ToUpper(err.Error())
+	return strings.Contains(msg, "WRONGTYPE") || strings.Contains(msg, "UNKNOWN COMMAND")
+}
+
+// tailViaPubsub is the degraded-mode fallback: no history replay, live
+// messages only. Each pubsub payload is the plain log line, pushed as a
+// logEntry with only Log set (no id, stream or ts).
+func (wsc *wsConnection) tailViaPubsub(ctx context.Context, subID, channel string) {
+	pubsub := wsc.api.subscriber.PSubscribe(ctx, channel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			wsc.notify(subID, "logs_subscription", logEntry{Log: msg.Payload})
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -21,9 +23,11 @@ import (
 )
 
 type APIServer struct {
-	ecsClient *ecs.Client
-	subscriber *redis.Client
-	config    AWSConfig
+	ecsClient     *ecs.Client
+	subscriber    *redis.Client
+	registry      Registry
+	callbackToken string
+	config        AWSConfig
 }
 
 type AWSConfig struct {
@@ -46,10 +50,11 @@ type ProjectResponse struct {
 type ProjectData struct {
 	ProjectSlug string `json:"projectSlug"`
 	URL         string `json:"url"`
-}
-
-type LogMessage struct {
-	Log string `json:"log"`
+	// OwnerToken is only populated the first time a slug is created. The
+	// caller must present it as X-Project-Owner-Token on later requests
+	// against this slug (re-deploys, notifier management) — it's not
+	// retrievable again after this response.
+	OwnerToken string `json:"ownerToken,omitempty"`
 }
 
 var upgrader = websocket.Upgrader{
@@ -98,10 +103,21 @@ func NewAPIServer() (*APIServer, error) {
 		SecurityGroups:  securityGroups,
 	}
 
+	registryPath := os.Getenv("REGISTRY_DB_PATH")
+	if registryPath == "" {
+		registryPath = "registry.db"
+	}
+	registry, err := NewBoltRegistry(registryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job registry: %w", err)
+	}
+
 	return &APIServer{
-		ecsClient:  ecsClient,
-		subscriber: subscriber,
-		config:     awsConfig,
+		ecsClient:     ecsClient,
+		subscriber:    subscriber,
+		registry:      registry,
+		callbackToken: os.Getenv("BUILD_CALLBACK_TOKEN"),
+		config:        awsConfig,
 	}, nil
 }
 
@@ -111,6 +127,12 @@ func generateSlug() string {
 	return strings.ReplaceAll(id[:8], "-", "")
 }
 
+// slugPattern restricts caller-supplied project slugs to a safe charset.
+// Slugs end up in places (SMTP headers, shell-adjacent env, URLs) where a
+// stray CR/LF or shell metacharacter would otherwise need escaping at
+// every one of those call sites individually.
+var slugPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]{0,62}$`)
+
 func (api *APIServer) createProject(c *gin.Context) {
 	var req ProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -121,45 +143,100 @@ func (api *APIServer) createProject(c *gin.Context) {
 	projectSlug := req.Slug
 	if projectSlug == "" {
 		projectSlug = generateSlug()
+	} else if !slugPattern.MatchString(projectSlug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "slug must match ^[a-zA-Z0-9][a-zA-Z0-9-]{0,62}$"})
+		return
 	}
 
-	// Create ECS task
-	taskInput := &ecs.RunTaskInput{
-		Cluster:        aws.String(api.config.Cluster),
-		TaskDefinition: aws.String(api.config.TaskDefinition),
-		LaunchType:     types.LaunchTypeFargate,
-		Count:          aws.Int32(1),
-		NetworkConfiguration: &types.NetworkConfiguration{
-			AwsvpcConfiguration: &types.AwsVpcConfiguration{
-				AssignPublicIp: types.AssignPublicIpEnabled,
-				Subnets:        api.config.Subnets,
-				SecurityGroups: api.config.SecurityGroups,
+	ownerToken, created, err := api.registry.EnsureProject(projectSlug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register project"})
+		return
+	}
+	if !created {
+		if ok, err := api.registry.VerifyProjectOwner(projectSlug, c.GetHeader("X-Project-Owner-Token")); err != nil || !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "slug already in use; supply its X-Project-Owner-Token to deploy again"})
+			return
+		}
+		ownerToken = ""
+	}
+
+	nodeVersions, gitSHA, err := readMatrix(req.GitURL)
+	if err != nil {
+		log.Printf("Failed to read pipeline config, falling back to a single build: %v", err)
+		nodeVersions = []string{""}
+	}
+
+	for _, nodeVersion := range nodeVersions {
+		deployment := Deployment{
+			ID:          uuid.New().String(),
+			ProjectSlug: projectSlug,
+			GitSHA:      gitSHA,
+			Status:      StatusPending,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		if err := api.registry.Create(deployment); err != nil {
+			log.Printf("Failed to create deployment record: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start build"})
+			return
+		}
+
+		env := []types.KeyValuePair{
+			{
+				Name:  aws.String("GIT_REPOSITORY__URL"),
+				Value: aws.String(req.GitURL),
 			},
-		},
-		Overrides: &types.TaskOverride{
-			ContainerOverrides: []types.ContainerOverride{
-				{
-					Name: aws.String("builder-image"),
-					Environment: []types.KeyValuePair{
-						{
-							Name:  aws.String("GIT_REPOSITORY__URL"),
-							Value: aws.String(req.GitURL),
-						},
-						{
-							Name:  aws.String("PROJECT_ID"),
-							Value: aws.String(projectSlug),
-						},
+			{
+				Name:  aws.String("PROJECT_ID"),
+				Value: aws.String(projectSlug),
+			},
+			{
+				Name:  aws.String("DEPLOYMENT_ID"),
+				Value: aws.String(deployment.ID),
+			},
+		}
+		if nodeVersion != "" {
+			env = append(env, types.KeyValuePair{
+				Name:  aws.String("MATRIX_NODE_VERSION"),
+				Value: aws.String(nodeVersion),
+			})
+		}
+
+		taskInput := &ecs.RunTaskInput{
+			Cluster:        aws.String(api.config.Cluster),
+			TaskDefinition: aws.String(api.config.TaskDefinition),
+			LaunchType:     types.LaunchTypeFargate,
+			Count:          aws.Int32(1),
+			NetworkConfiguration: &types.NetworkConfiguration{
+				AwsvpcConfiguration: &types.AwsVpcConfiguration{
+					AssignPublicIp: types.AssignPublicIpEnabled,
+					Subnets:        api.config.Subnets,
+					SecurityGroups: api.config.SecurityGroups,
+				},
+			},
+			Overrides: &types.TaskOverride{
+				ContainerOverrides: []types.ContainerOverride{
+					{
+						Name:        aws.String("builder-image"),
+						Environment: env,
 					},
 				},
 			},
-		},
-	}
+		}
 
-	_, err := api.ecsClient.RunTask(context.Background(), taskInput)
-	if err != nil {
-		log.Printf("Failed to run ECS task: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start build"})
-		return
+		out, err := api.ecsClient.RunTask(context.Background(), taskInput)
+		if err != nil {
+			log.Printf("Failed to run ECS task: %v", err)
+			api.registry.UpdateStatus(deployment.ID, StatusFailure, "")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start build"})
+			return
+		}
+		if len(out.Tasks) > 0 && out.Tasks[0].TaskArn != nil {
+			if err := api.registry.SetTaskARN(deployment.ID, *out.Tasks[0].TaskArn); err != nil {
+				log.Printf("Failed to record task ARN: %v", err)
+			}
+		}
 	}
 
 	response := ProjectResponse{
@@ -167,73 +244,133 @@ func (api *APIServer) createProject(c *gin.Context) {
 		Data: ProjectData{
 			ProjectSlug: projectSlug,
 			URL:         fmt.Sprintf("http://%s.localhost:8000", projectSlug),
+			OwnerToken:  ownerToken,
 		},
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-func (api *APIServer) handleWebSocket(c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+// getProject returns every deployment recorded for a project slug, in
+// registry key order (not sorted by time).
+func (api *APIServer) getProject(c *gin.Context) {
+	slug := c.Param("slug")
+	deployments, err := api.registry.ListByProject(slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"projectSlug": slug, "deployments": deployments})
+}
+
+// listDeployments is an alias of getProject kept as its own route since
+// the two are expected to diverge (pagination, filtering) once the UI
+// needs it.
+func (api *APIServer) listDeployments(c *gin.Context) {
+	api.getProject(c)
+}
+
+func (api *APIServer) getDeployment(c *gin.Context) {
+	deployment, err := api.registry.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deployment)
+}
+
+func (api *APIServer) cancelDeployment(c *gin.Context) {
+	id := c.Param("id")
+	deployment, err := api.registry.Get(id)
 	if err != nil {
-		log.Printf("Failed to upgrade connection: %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	defer conn.Close()
 
-	// Handle WebSocket messages
-	for {
-		var msg map[string]interface{}
-		err := conn.ReadJSON(&msg)
+	if deployment.TaskARN != "" {
+		_, err := api.ecsClient.StopTask(context.Background(), &ecs.StopTaskInput{
+			Cluster: aws.String(api.config.Cluster),
+			Task:    aws.String(deployment.TaskARN),
+			Reason:  aws.String("cancelled by user"),
+		})
 		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
-			break
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to stop task: %v", err)})
+			return
 		}
+	}
 
-		if action, ok := msg["action"].(string); ok && action == "subscribe" {
-			if channel, ok := msg["channel"].(string); ok {
-				// Subscribe to Redis channel and forward messages
-				go api.subscribeAndForward(conn, channel)
-				
-				// Send confirmation
-				conn.WriteJSON(map[string]string{
-					"message": fmt.Sprintf("Joined %s", channel),
-				})
-			}
-		}
+	if err := api.registry.UpdateStatus(id, StatusCancelled, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"status": StatusCancelled})
 }
 
-func (api *APIServer) subscribeAndForward(conn *websocket.Conn, channel string) {
-	pubsub := api.subscriber.PSubscribe(context.Background(), channel)
-	defer pubsub.Close()
+// deploymentStatusCallback lets the build container report its own
+// lifecycle transitions (running, success, failure) without the API
+// server having to poll ECS.
+type deploymentStatusCallback struct {
+	Status      DeploymentStatus `json:"status" binding:"required"`
+	ArtifactURL string           `json:"artifactURL"`
+}
 
-	ch := pubsub.Channel()
-	for msg := range ch {
-		var logMsg LogMessage
-		if err := json.Unmarshal([]byte(msg.Payload), &logMsg); err != nil {
-			log.Printf("Failed to unmarshal log message: %v", err)
-			continue
-		}
+func (api *APIServer) updateDeploymentStatus(c *gin.Context) {
+	if api.callbackToken != "" && c.GetHeader("Authorization") != "Bearer "+api.callbackToken {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid callback token"})
+		return
+	}
 
-		err := conn.WriteJSON(map[string]string{
-			"message": msg.Payload,
-		})
-		if err != nil {
-			log.Printf("WebSocket write error: %v", err)
-			break
-		}
+	var body deploymentStatusCallback
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+
+	id := c.Param("id")
+	if err := api.registry.UpdateStatus(id, body.Status, body.ArtifactURL); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if deployment, err := api.registry.Get(id); err == nil {
+		api.publishStatus(deployment)
+		api.dispatchNotifications(deployment)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": body.Status})
 }
 
-func (api *APIServer) initRedisSubscribe() {
-	log.Println("Subscribed to logs....")
-	
-	pubsub := api.subscriber.PSubscribe(context.Background(), "logs:*")
-	defer pubsub.Close()
+// requireProjectOwner checks the X-Project-Owner-Token header against the
+// slug's stored owner token, writing the response and returning false if
+// it doesn't match. Routes that manage a project's notifiers (which can
+// read and redirect that project's deployment status/artifact URL) must
+// call this before acting, since the slug itself isn't a secret.
+func (api *APIServer) requireProjectOwner(c *gin.Context) bool {
+	slug := c.Param("slug")
+	ok, err := api.registry.VerifyProjectOwner(slug, c.GetHeader("X-Project-Owner-Token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "missing or invalid X-Project-Owner-Token"})
+		return false
+	}
+	return true
+}
 
-	// This will be handled per WebSocket connection
-	// The actual subscription forwarding happens in subscribeAndForward
+// publishStatus notifies any status_subscribe'd WebSocket clients of a
+// deployment's current state.
+func (api *APIServer) publishStatus(d Deployment) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		log.Printf("Failed to marshal status update: %v", err)
+		return
+	}
+	channel := fmt.Sprintf("status:%s", d.ProjectSlug)
+	if err := api.subscriber.Publish(context.Background(), channel, data).Err(); err != nil {
+		log.Printf("Failed to publish status update: %v", err)
+	}
 }
 
 func setupRoutes(api *APIServer) *gin.Engine {
@@ -254,6 +391,14 @@ func setupRoutes(api *APIServer) *gin.Engine {
 	})
 
 	r.POST("/project", api.createProject)
+	r.GET("/project/:slug", api.getProject)
+	r.GET("/project/:slug/deployments", api.listDeployments)
+	r.POST("/project/:slug/notifiers", api.createNotifier)
+	r.GET("/project/:slug/notifiers", api.listNotifiers)
+	r.DELETE("/project/:slug/notifiers/:id", api.deleteNotifier)
+	r.GET("/deployment/:id", api.getDeployment)
+	r.POST("/deployment/:id/cancel", api.cancelDeployment)
+	r.POST("/deployment/:id/status", api.updateDeploymentStatus)
 	r.GET("/ws", api.handleWebSocket)
 
 	return r
@@ -265,9 +410,6 @@ func main() {
 		log.Fatalf("Failed to initialize API server: %v", err)
 	}
 
-	// Initialize Redis subscription
-	go apiServer.initRedisSubscribe()
-
 	// Setup routes
 	router := setupRoutes(apiServer)
 
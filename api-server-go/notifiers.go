@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+func init() {
+	RegisterNotifier("webhook", newWebhookNotifier)
+	RegisterNotifier("slack", newSlackNotifier)
+	RegisterNotifier("discord", newDiscordNotifier)
+	RegisterNotifier("smtp", newSMTPNotifier)
+}
+
+// globalNotifiersFromEnv builds the process-wide notifiers configured
+// via env vars, used alongside whatever a project has configured for
+// itself.
+func globalNotifiersFromEnv() []Notifier {
+	var notifiers []Notifier
+
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		if n, err := newWebhookNotifier(map[string]string{
+			"url":    url,
+			"secret": os.Getenv("NOTIFY_WEBHOOK_SECRET"),
+		}); err == nil {
+			notifiers = append(notifiers, n)
+		}
+	}
+	if url := os.Getenv("NOTIFY_SLACK_URL"); url != "" {
+		if n, err := newSlackNotifier(map[string]string{"url": url}); err == nil {
+			notifiers = append(notifiers, n)
+		}
+	}
+
+	return notifiers
+}
+
+// webhookNotifier POSTs the event as JSON, signed with HMAC-SHA256 over
+// the raw body so receivers can verify authenticity.
+type webhookNotifier struct {
+	url    string
+	secret string
+}
+
+func newWebhookNotifier(config map[string]string) (Notifier, error) {
+	url := config["url"]
+	if url == "" {
+		return nil, fmt.Errorf("webhook notifier requires a url")
+	}
+	return &webhookNotifier{url: url, secret: config["secret"]}, nil
+}
+
+func (w *webhookNotifier) Name() string { return "webhook:" + w.url }
+
+func (w *webhookNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return doNotifyRequest(req)
+}
+
+// slackNotifier posts a simple text message to a Slack incoming
+// webhook.
+type slackNotifier struct {
+	url string
+}
+
+func newSlackNotifier(config map[string]string) (Notifier, error) {
+	url := config["url"]
+	if url == "" {
+		return nil, fmt.Errorf("slack notifier requires a url")
+	}
+	return &slackNotifier{url: url}, nil
+}
+
+func (s *slackNotifier) Name() string { return "slack:" + s.url }
+
+func (s *slackNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	body, err := json.Marshal(map[string]string{"text": formatNotification(event)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doNotifyRequest(req)
+}
+
+// discordNotifier posts to a Discord webhook using Discord's own
+// {"content": "..."} shape (Slack's webhook takes {"text": "..."}).
+type discordNotifier struct {
+	url string
+}
+
+func newDiscordNotifier(config map[string]string) (Notifier, error) {
+	url := config["url"]
+	if url == "" {
+		return nil, fmt.Errorf("discord notifier requires a url")
+	}
+	return &discordNotifier{url: url}, nil
+}
+
+func (d *discordNotifier) Name() string { return "discord:" + d.url }
+
+func (d *discordNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	body, err := json.Marshal(map[string]string{"content": formatNotification(event)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doNotifyRequest(req)
+}
+
+// smtpNotifier emails the project owner on terminal build states.
+type smtpNotifier struct {
+	host, port, username, password, from, to string
+}
+
+func newSMTPNotifier(config map[string]string) (Notifier, error) {
+	n := &smtpNotifier{
+		host:     config["host"],
+		port:     config["port"],
+		username: config["username"],
+		password: config["password"],
+		from:     config["from"],
+		to:       config["to"],
+	}
+	if n.host == "" || n.to == "" || n.from == "" {
+		return nil, fmt.Errorf("smtp notifier requires host, from, and to")
+	}
+	if n.port == "" {
+		n.port = "587"
+	}
+	return n, nil
+}
+
+func (s *smtpNotifier) Name() string { return "smtp:" + s.to }
+
+func (s *smtpNotifier) Notify(ctx context.Context, event NotificationEvent) error {
+	addr := fmt.Sprintf("%s:%s", s.host, s.port)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Deployment %s %s\r\n\r\n%s\r\n",
+		s.from, s.to, stripCRLF(event.ProjectSlug), event.Status, formatNotification(event))
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	return smtp.SendMail(addr, auth, s.from, []string{s.to}, []byte(msg))
+}
+
+// stripCRLF removes CR/LF from a value before it's interpolated into a
+// raw SMTP header line, so a caller-supplied field (e.g. a project slug)
+// can't inject extra headers or a second message into the mail.
+func stripCRLF(s string) string {
+	s = strings.ReplaceAll(s, "\r", "")
+	s = strings.ReplaceAll(s, "\n", "")
+	return s
+}
+
+func formatNotification(event NotificationEvent) string {
+	msg := fmt.Sprintf("Deployment %s for %s: %s", event.DeploymentID, event.ProjectSlug, event.Status)
+	if event.ArtifactURL != "" {
+		msg += fmt.Sprintf(" (%s)", event.ArtifactURL)
+	}
+	return msg
+}
+
+func doNotifyRequest(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notifier request to %s returned %d", req.URL, resp.StatusCode)
+	}
+	return nil
+}
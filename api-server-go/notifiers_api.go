@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type createNotifierRequest struct {
+	Kind   string            `json:"kind" binding:"required"`
+	Config map[string]string `json:"config"`
+}
+
+// createNotifier, listNotifiers, and deleteNotifier all require the
+// caller to present the project's owner token (returned once, as
+// ownerToken in createProject's response) in the X-Project-Owner-Token
+// header — otherwise the slug alone
+// would be enough for anyone to attach a notifier and passively receive
+// that project's deployment status and artifact URL on every future
+// build, or read/delete notifiers they didn't create.
+func (api *APIServer) createNotifier(c *gin.Context) {
+	if !api.requireProjectOwner(c) {
+		return
+	}
+
+	var req createNotifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg := NotifierConfig{
+		ID:          uuid.New().String(),
+		ProjectSlug: c.Param("slug"),
+		Kind:        req.Kind,
+		Config:      req.Config,
+		CreatedAt:   time.Now(),
+	}
+
+	// Validate the notifier builds before persisting a broken config.
+	if _, err := cfg.build(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := api.registry.CreateNotifierConfig(cfg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, cfg)
+}
+
+func (api *APIServer) listNotifiers(c *gin.Context) {
+	if !api.requireProjectOwner(c) {
+		return
+	}
+
+	configs, err := api.registry.ListNotifierConfigs(c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"notifiers": configs})
+}
+
+func (api *APIServer) deleteNotifier(c *gin.Context) {
+	if !api.requireProjectOwner(c) {
+		return
+	}
+
+	if err := api.registry.DeleteNotifierConfig(c.Param("slug"), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
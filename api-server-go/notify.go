@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationEvent is what every Notifier receives at a deployment's
+// terminal state.
+type NotificationEvent struct {
+	DeploymentID string           `json:"deploymentId"`
+	ProjectSlug  string           `json:"projectSlug"`
+	Status       DeploymentStatus `json:"status"`
+	ArtifactURL  string           `json:"artifactURL,omitempty"`
+	Timestamp    time.Time        `json:"timestamp"`
+}
+
+// Notifier is deliberately small so additional targets can be
+// registered at compile time via RegisterNotifier from a new file's
+// init(), without changing the existing notifier code.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event NotificationEvent) error
+}
+
+// NotifierFactory builds a Notifier from its stored config (e.g. the
+// webhook URL, a Slack incoming-webhook URL, SMTP credentials).
+type NotifierFactory func(config map[string]string) (Notifier, error)
+
+var notifierFactories = map[string]NotifierFactory{}
+
+// RegisterNotifier makes a notifier kind available to NotifierConfig.
+// Built-in kinds register themselves from init() in notifiers.go; any
+// other file of this package can call it the same way from its own
+// init() (this is package main, so other packages cannot import it).
+func RegisterNotifier(kind string, factory NotifierFactory) {
+	notifierFactories[kind] = factory
+}
+
+// NotifierConfig is a stored, CRUD-able notification target for a
+// project, as created via POST /project/:slug/notifiers.
+type NotifierConfig struct {
+	ID          string            `json:"id"`
+	ProjectSlug string            `json:"projectSlug"`
+	Kind        string            `json:"kind"`
+	Config      map[string]string `json:"config"`
+	CreatedAt   time.Time         `json:"createdAt"`
+}
+
+func (c NotifierConfig) build() (Notifier, error) {
+	factory, ok := notifierFactories[c.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown notifier kind %q", c.Kind)
+	}
+	return factory(c.Config)
+}
+
+const (
+	notifyMaxAttempts   = 4
+	notifyBaseBackoff   = time.Second
+	notifyDeadLetterKey = "notify:deadletter"
+)
+
+// dispatchNotifications fans an event out to every notifier configured
+// for the project plus whatever's configured globally via env, each on
+// its own goroutine so a slow webhook never blocks the status callback.
+func (api *APIServer) dispatchNotifications(d Deployment) {
+	if d.Status != StatusSuccess && d.Status != StatusFailure && d.Status != StatusCancelled {
+		return
+	}
+
+	event := NotificationEvent{
+		DeploymentID: d.ID,
+		ProjectSlug:  d.ProjectSlug,
+		Status:       d.Status,
+		ArtifactURL:  d.ArtifactURL,
+		Timestamp:    time.Now(),
+	}
+
+	configs, err := api.registry.ListNotifierConfigs(d.ProjectSlug)
+	if err != nil {
+		log.Printf("Failed to load notifier configs for %s: %v", d.ProjectSlug, err)
+	}
+
+	var notifiers []Notifier
+	for _, cfg := range configs {
+		n, err := cfg.build()
+		if err != nil {
+			log.Printf("Failed to build notifier %s: %v", cfg.ID, err)
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	notifiers = append(notifiers, globalNotifiersFromEnv()...)
+
+	for _, n := range notifiers {
+		go api.sendWithRetry(n, event)
+	}
+}
+
+// sendWithRetry delivers event with exponential backoff; a notifier
+// that still fails after notifyMaxAttempts is pushed onto a Redis
+// dead-letter list for manual inspection/redelivery instead of being
+// silently dropped.
+func (api *APIServer) sendWithRetry(n Notifier, event NotificationEvent) {
+	var lastErr error
+	for attempt := 0; attempt < notifyMaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(notifyBaseBackoff << (attempt - 1))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := n.Notify(ctx, event)
+		cancel()
+
+		if err == nil {
+			return
+		}
+		lastErr = err
+		log.Printf("Notifier %s attempt %d/%d failed: %v", n.Name(), attempt+1, notifyMaxAttempts, err)
+	}
+
+	api.deadLetterNotification(n, event, lastErr)
+}
+
+func (api *APIServer) deadLetterNotification(n Notifier, event NotificationEvent, cause error) {
+	payload := map[string]interface{}{
+		"id":       uuid.New().String(),
+		"notifier": n.Name(),
+		"event":    event,
+		"error":    cause.Error(),
+		"failedAt": time.Now(),
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to marshal dead-letter payload for %s: %v", n.Name(), err)
+		return
+	}
+	if err := api.subscriber.RPush(context.Background(), notifyDeadLetterKey, data).Err(); err != nil {
+		log.Printf("Failed to dead-letter notification for %s: %v", n.Name(), err)
+	}
+}
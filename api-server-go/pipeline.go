@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configPaths mirrors the set of pipeline config locations the build
+// server looks for inside the repo it checks out.
+var configPaths = []string{
+	"vercel.yaml",
+	"vercel.yml",
+	".vercel/pipeline.yaml",
+	".vercel/pipeline.yml",
+}
+
+// matrixConfig is the subset of the pipeline schema the API server
+// needs before it can decide how many ECS tasks to launch. The build
+// server owns the full Pipeline/Step definition.
+type matrixConfig struct {
+	Matrix *struct {
+		Node []string `yaml:"node"`
+	} `yaml:"matrix,omitempty"`
+}
+
+// allowedGitSchemes is the set of URL schemes readMatrix will shell out
+// to git with. req.GitURL comes straight from an unauthenticated POST
+// /project body, and git clone honors things like the ext:: remote
+// helper (arbitrary command execution) and file:// (arbitrary local
+// file read) for schemes we have no business supporting here.
+var allowedGitSchemes = []string{"https://", "git://"}
+
+func validateGitURL(gitURL string) error {
+	for _, scheme := range allowedGitSchemes {
+		if strings.HasPrefix(gitURL, scheme) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported git URL scheme (must be https:// or git://)")
+}
+
+// readMatrix does a shallow, blobless clone of gitURL and looks for a
+// pipeline config to read its matrix section, returning the node
+// versions to fan out across (a single empty-string entry if there's
+// no matrix, meaning "one task") along with the commit SHA that was
+// actually cloned, so callers can record exactly what got deployed.
+func readMatrix(gitURL string) (nodeVersions []string, gitSHA string, err error) {
+	if err := validateGitURL(gitURL); err != nil {
+		return nil, "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "pipeline-config-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cmd := exec.Command("git", "clone", "--depth", "1", "--filter=blob:none", "--", gitURL, tmpDir)
+	if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("failed to clone %s: %w", gitURL, err)
+	}
+
+	gitSHA, err = resolveHead(tmpDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve HEAD for %s: %w", gitURL, err)
+	}
+
+	for _, rel := range configPaths {
+		data, err := os.ReadFile(filepath.Join(tmpDir, rel))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, gitSHA, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		var cfg matrixConfig
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, gitSHA, fmt.Errorf("failed to parse %s: %w", rel, err)
+		}
+		if cfg.Matrix != nil && len(cfg.Matrix.Node) > 0 {
+			return cfg.Matrix.Node, gitSHA, nil
+		}
+		return []string{""}, gitSHA, nil
+	}
+
+	return []string{""}, gitSHA, nil
+}
+
+// resolveHead reads back the commit SHA that a clone into repoDir landed
+// on, so a --depth 1 clone's HEAD can be recorded even though it has no
+// meaningful history to walk.
+func resolveHead(repoDir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = repoDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// DeploymentStatus is the lifecycle state of a single deployment.
+type DeploymentStatus string
+
+const (
+	StatusPending   DeploymentStatus = "pending"
+	StatusRunning   DeploymentStatus = "running"
+	StatusSuccess   DeploymentStatus = "success"
+	StatusFailure   DeploymentStatus = "failure"
+	StatusCancelled DeploymentStatus = "cancelled"
+)
+
+// Deployment is one build/deploy attempt for a project.
+type Deployment struct {
+	ID          string           `json:"id"`
+	ProjectSlug string           `json:"projectSlug"`
+	GitSHA      string           `json:"gitSHA,omitempty"`
+	TaskARN     string           `json:"taskARN,omitempty"`
+	Status      DeploymentStatus `json:"status"`
+	ArtifactURL string           `json:"artifactURL,omitempty"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+}
+
+// Registry tracks deployments across their lifetime. Implementations
+// must be safe for concurrent use.
+type Registry interface {
+	Create(d Deployment) error
+	Get(id string) (Deployment, error)
+	ListByProject(projectSlug string) ([]Deployment, error)
+	UpdateStatus(id string, status DeploymentStatus, artifactURL string) error
+	SetTaskARN(id string, taskARN string) error
+
+	CreateNotifierConfig(cfg NotifierConfig) error
+	ListNotifierConfigs(projectSlug string) ([]NotifierConfig, error)
+	DeleteNotifierConfig(projectSlug, id string) error
+
+	// EnsureProject returns the owner token a slug was first created
+	// with, generating and persisting one if the slug is new (created
+	// reports which case happened).
+	EnsureProject(slug string) (ownerToken string, created bool, err error)
+	// VerifyProjectOwner reports whether token matches the slug's
+	// stored owner token.
+	VerifyProjectOwner(slug, token string) (bool, error)
+
+	Close() error
+}
+
+var (
+	deploymentsBucket   = []byte("deployments")
+	projectIndexBucket  = []byte("project_index")
+	notifiersBucket     = []byte("notifiers")
+	notifierIndexBucket = []byte("notifier_project_index")
+	projectsBucket      = []byte("projects")
+)
+
+// BoltRegistry is the default Registry, backed by a local BoltDB file.
+type BoltRegistry struct {
+	db *bbolt.DB
+}
+
+// NewBoltRegistry opens (creating if necessary) a BoltDB-backed Registry
+// at path.
+func NewBoltRegistry(path string) (*BoltRegistry, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open registry db: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{deploymentsBucket, projectIndexBucket, notifiersBucket, notifierIndexBucket, projectsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init registry buckets: %w", err)
+	}
+
+	return &BoltRegistry{db: db}, nil
+}
+
+func (r *BoltRegistry) Create(d Deployment) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(deploymentsBucket).Put([]byte(d.ID), data); err != nil {
+			return err
+		}
+
+		idx := tx.Bucket(projectIndexBucket)
+		key := []byte(d.ProjectSlug + "/" + d.ID)
+		return idx.Put(key, []byte(d.ID))
+	})
+}
+
+func (r *BoltRegistry) Get(id string) (Deployment, error) {
+	var d Deployment
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(deploymentsBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("deployment %q not found", id)
+		}
+		return json.Unmarshal(data, &d)
+	})
+	return d, err
+}
+
+func (r *BoltRegistry) ListByProject(projectSlug string) ([]Deployment, error) {
+	var deployments []Deployment
+	prefix := []byte(projectSlug + "/")
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(projectIndexBucket).Cursor()
+		deploymentsBkt := tx.Bucket(deploymentsBucket)
+
+		for k, v := c.Seek(prefix); k != nil && hasPrefix(k, prefix); k, v = c.Next() {
+			data := deploymentsBkt.Get(v)
+			if data == nil {
+				continue
+			}
+			var d Deployment
+			if err := json.Unmarshal(data, &d); err != nil {
+				return err
+			}
+			deployments = append(deployments, d)
+		}
+		return nil
+	})
+	return deployments, err
+}
+
+func (r *BoltRegistry) UpdateStatus(id string, status DeploymentStatus, artifactURL string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bkt := tx.Bucket(deploymentsBucket)
+		data := bkt.Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("deployment %q not found", id)
+		}
+		var d Deployment
+		if err := json.Unmarshal(data, &d); err != nil {
+			return err
+		}
+		d.Status = status
+		if artifactURL != "" {
+			d.ArtifactURL = artifactURL
+		}
+		d.UpdatedAt = time.Now()
+
+		updated, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(id), updated)
+	})
+}
+
+func (r *BoltRegistry) SetTaskARN(id string, taskARN string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bkt := tx.Bucket(deploymentsBucket)
+		data := bkt.Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("deployment %q not found", id)
+		}
+		var d Deployment
+		if err := json.Unmarshal(data, &d); err != nil {
+			return err
+		}
+		d.TaskARN = taskARN
+		d.UpdatedAt = time.Now()
+
+		updated, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(id), updated)
+	})
+}
+
+func (r *BoltRegistry) Close() error {
+	return r.db.Close()
+}
+
+func hasPrefix(b, prefix []byte) bool {
+	if len(b) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if b[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
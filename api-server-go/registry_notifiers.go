@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+func (r *BoltRegistry) CreateNotifierConfig(cfg NotifierConfig) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(notifiersBucket).Put([]byte(cfg.ID), data); err != nil {
+			return err
+		}
+
+		idx := tx.Bucket(notifierIndexBucket)
+		key := []byte(cfg.ProjectSlug + "/" + cfg.ID)
+		return idx.Put(key, []byte(cfg.ID))
+	})
+}
+
+func (r *BoltRegistry) ListNotifierConfigs(projectSlug string) ([]NotifierConfig, error) {
+	var configs []NotifierConfig
+	prefix := []byte(projectSlug + "/")
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(notifierIndexBucket).Cursor()
+		notifiersBkt := tx.Bucket(notifiersBucket)
+
+		for k, v := c.Seek(prefix); k != nil && hasPrefix(k, prefix); k, v = c.Next() {
+			data := notifiersBkt.Get(v)
+			if data == nil {
+				continue
+			}
+			var cfg NotifierConfig
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return err
+			}
+			configs = append(configs, cfg)
+		}
+		return nil
+	})
+	return configs, err
+}
+
+func (r *BoltRegistry) DeleteNotifierConfig(projectSlug, id string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(notifiersBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		key := []byte(projectSlug + "/" + id)
+		if tx.Bucket(notifierIndexBucket).Get(key) == nil {
+			return fmt.Errorf("notifier %q not found for project %q", id, projectSlug)
+		}
+		return tx.Bucket(notifierIndexBucket).Delete(key)
+	})
+}
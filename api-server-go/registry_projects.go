@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.etcd.io/bbolt"
+)
+
+// project records the owner token a project's slug was first created
+// with, so later requests against that slug (re-deploying, attaching
+// notifiers) can be checked against it instead of being open to anyone
+// who knows or guesses the slug.
+type project struct {
+	Slug       string    `json:"slug"`
+	OwnerToken string    `json:"ownerToken"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (r *BoltRegistry) EnsureProject(slug string) (ownerToken string, created bool, err error) {
+	err = r.db.Update(func(tx *bbolt.Tx) error {
+		bkt := tx.Bucket(projectsBucket)
+		if data := bkt.Get([]byte(slug)); data != nil {
+			var p project
+			if err := json.Unmarshal(data, &p); err != nil {
+				return err
+			}
+			ownerToken = p.OwnerToken
+			return nil
+		}
+
+		p := project{Slug: slug, OwnerToken: uuid.New().String(), CreatedAt: time.Now()}
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		ownerToken = p.OwnerToken
+		created = true
+		return bkt.Put([]byte(slug), data)
+	})
+	return ownerToken, created, err
+}
+
+func (r *BoltRegistry) VerifyProjectOwner(slug, token string) (bool, error) {
+	var ok bool
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(projectsBucket).Get([]byte(slug))
+		if data == nil {
+			return fmt.Errorf("project %q not found", slug)
+		}
+		var p project
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		ok = token != "" && p.OwnerToken == token
+		return nil
+	})
+	return ok, err
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHasPrefix(t *testing.T) {
+	tests := []struct {
+		b, prefix string
+		want      bool
+	}{
+		{"my-project/abc123", "my-project/", true},
+		{"my-project-2/abc123", "my-project/", false},
+		{"short", "longer-prefix", false},
+		{"exact", "exact", true},
+	}
+
+	for _, tt := range tests {
+		got := hasPrefix([]byte(tt.b), []byte(tt.prefix))
+		if got != tt.want {
+			t.Errorf("hasPrefix(%q, %q) = %v, want %v", tt.b, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestBoltRegistryDeploymentRoundtrip(t *testing.T) {
+	reg, err := NewBoltRegistry(filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("NewBoltRegistry: %v", err)
+	}
+	defer reg.Close()
+
+	d := Deployment{
+		ID:          "dep-1",
+		ProjectSlug: "my-project",
+		GitSHA:      "abc123",
+		Status:      StatusPending,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := reg.Create(d); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := reg.Get(d.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.GitSHA != d.GitSHA || got.ProjectSlug != d.ProjectSlug {
+		t.Errorf("Get returned %+v, want %+v", got, d)
+	}
+
+	if err := reg.SetTaskARN(d.ID, "arn:aws:ecs:task/1"); err != nil {
+		t.Fatalf("SetTaskARN: %v", err)
+	}
+	if err := reg.UpdateStatus(d.ID, StatusSuccess, "https://example.com/artifact"); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	got, err = reg.Get(d.ID)
+	if err != nil {
+		t.Fatalf("Get after update: %v", err)
+	}
+	if got.TaskARN != "arn:aws:ecs:task/1" || got.Status != StatusSuccess || got.ArtifactURL != "https://example.com/artifact" {
+		t.Errorf("Get after update returned %+v", got)
+	}
+
+	list, err := reg.ListByProject("my-project")
+	if err != nil {
+		t.Fatalf("ListByProject: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != d.ID {
+		t.Errorf("ListByProject returned %+v, want a single deployment %q", list, d.ID)
+	}
+
+	if list, err := reg.ListByProject("other-project"); err != nil || len(list) != 0 {
+		t.Errorf("ListByProject(other-project) = %+v, %v, want empty", list, err)
+	}
+}
+
+func TestBoltRegistryEnsureAndVerifyProject(t *testing.T) {
+	reg, err := NewBoltRegistry(filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("NewBoltRegistry: %v", err)
+	}
+	defer reg.Close()
+
+	token, created, err := reg.EnsureProject("my-project")
+	if err != nil {
+		t.Fatalf("EnsureProject: %v", err)
+	}
+	if !created || token == "" {
+		t.Fatalf("EnsureProject first call = (%q, %v), want a non-empty token and created=true", token, created)
+	}
+
+	again, created, err := reg.EnsureProject("my-project")
+	if err != nil {
+		t.Fatalf("EnsureProject (second call): %v", err)
+	}
+	if created || again != token {
+		t.Fatalf("EnsureProject second call = (%q, %v), want (%q, false)", again, created, token)
+	}
+
+	if ok, err := reg.VerifyProjectOwner("my-project", token); err != nil || !ok {
+		t.Errorf("VerifyProjectOwner with the correct token = %v, %v, want true, nil", ok, err)
+	}
+	if ok, _ := reg.VerifyProjectOwner("my-project", "wrong-token"); ok {
+		t.Error("VerifyProjectOwner with an incorrect token = true, want false")
+	}
+	if _, err := reg.VerifyProjectOwner("no-such-project", token); err == nil {
+		t.Error("VerifyProjectOwner for an unknown project = nil error, want an error")
+	}
+}
+
+func TestBoltRegistryNotifierConfigRoundtrip(t *testing.T) {
+	reg, err := NewBoltRegistry(filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("NewBoltRegistry: %v", err)
+	}
+	defer reg.Close()
+
+	cfg := NotifierConfig{
+		ID:          "notif-1",
+		ProjectSlug: "my-project",
+		Kind:        "webhook",
+		Config:      map[string]string{"url": "https://example.com/hook"},
+		CreatedAt:   time.Now(),
+	}
+	if err := reg.CreateNotifierConfig(cfg); err != nil {
+		t.Fatalf("CreateNotifierConfig: %v", err)
+	}
+
+	list, err := reg.ListNotifierConfigs("my-project")
+	if err != nil {
+		t.Fatalf("ListNotifierConfigs: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != cfg.ID {
+		t.Errorf("ListNotifierConfigs returned %+v, want a single config %q", list, cfg.ID)
+	}
+
+	if err := reg.DeleteNotifierConfig("my-project", cfg.ID); err != nil {
+		t.Fatalf("DeleteNotifierConfig: %v", err)
+	}
+
+	list, err = reg.ListNotifierConfigs("my-project")
+	if err != nil {
+		t.Fatalf("ListNotifierConfigs after delete: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("ListNotifierConfigs after delete returned %+v, want empty", list)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/google/uuid"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = (wsPongWait * 9) / 10
+)
+
+// JSON-RPC 2.0 framing, Ethereum-style: requests carry a method and
+// params, subscriptions are created with a *_subscribe call and torn
+// down with *_unsubscribe, and push updates arrive as notifications
+// correlated by a server-generated subscriptionId.
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotificationParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+type rpcNotification struct {
+	JSONRPC string                `json:"jsonrpc"`
+	Method  string                `json:"method"`
+	Params  rpcNotificationParams `json:"params"`
+}
+
+type logsSubscribeParams struct {
+	Project string `json:"project"`
+	From    string `json:"from"`
+}
+
+type statusSubscribeParams struct {
+	Project string `json:"project"`
+}
+
+// wsWriteMsg is queued on a connection's write channel so every frame
+// (notifications and pings alike) goes through the single writer
+// goroutine and never races on conn.WriteMessage.
+type wsWriteMsg struct {
+	messageType int
+	data        []byte
+}
+
+// wsConnection wraps one upgraded WebSocket with its live subscriptions
+// and a dedicated writer goroutine.
+type wsConnection struct {
+	api     *APIServer
+	conn    *websocket.Conn
+	writeCh chan wsWriteMsg
+
+	mu   sync.Mutex
+	subs map[string]context.CancelFunc
+}
+
+func (api *APIServer) handleWebSocket(c *gin.Context) {
+	raw, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade connection: %v", err)
+		return
+	}
+
+	wsc := &wsConnection{
+		api:     api,
+		conn:    raw,
+		writeCh: make(chan wsWriteMsg, 64),
+		subs:    make(map[string]context.CancelFunc),
+	}
+
+	go wsc.writeLoop()
+	wsc.readLoop()
+}
+
+func (wsc *wsConnection) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		wsc.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-wsc.writeCh:
+			if !ok {
+				return
+			}
+			wsc.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := wsc.conn.WriteMessage(msg.messageType, msg.data); err != nil {
+				log.Printf("WebSocket write error: %v", err)
+				return
+			}
+		case <-ticker.C:
+			wsc.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := wsc.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (wsc *wsConnection) readLoop() {
+	defer wsc.close()
+
+	wsc.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	wsc.conn.SetPongHandler(func(string) error {
+		wsc.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		return nil
+	})
+
+	for {
+		var req rpcRequest
+		if err := wsc.conn.ReadJSON(&req); err != nil {
+			log.Printf("WebSocket read error: %v", err)
+			return
+		}
+		wsc.dispatch(req)
+	}
+}
+
+func (wsc *wsConnection) close() {
+	wsc.mu.Lock()
+	for _, cancel := range wsc.subs {
+		cancel()
+	}
+	wsc.subs = nil
+	wsc.mu.Unlock()
+	close(wsc.writeCh)
+}
+
+func (wsc *wsConnection) dispatch(req rpcRequest) {
+	switch req.Method {
+	case "ping":
+		wsc.reply(req.ID, "pong", nil)
+
+	case "logs_subscribe":
+		var params logsSubscribeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Project == "" {
+			wsc.reply(req.ID, nil, &rpcError{Code: -32602, Message: "invalid params: project is required"})
+			return
+		}
+		subID := wsc.subscribeLogs(params.Project, params.From)
+		wsc.reply(req.ID, subID, nil)
+
+	case "status_subscribe":
+		var params statusSubscribeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Project == "" {
+			wsc.reply(req.ID, nil, &rpcError{Code: -32602, Message: "invalid params: project is required"})
+			return
+		}
+		subID := wsc.subscribe(fmt.Sprintf("status:%s", params.Project), "status_subscription")
+		wsc.reply(req.ID, subID, nil)
+
+	case "logs_unsubscribe", "status_unsubscribe":
+		var subID string
+		if err := json.Unmarshal(req.Params, &subID); err != nil {
+			// Also accept {"subscription": "..."} for symmetry with notifications.
+			var params struct {
+				Subscription string `json:"subscription"`
+			}
+			if err := json.Unmarshal(req.Params, &params); err != nil || params.Subscription == "" {
+				wsc.reply(req.ID, nil, &rpcError{Code: -32602, Message: "invalid params: subscriptionId is required"})
+				return
+			}
+			subID = params.Subscription
+		}
+		wsc.reply(req.ID, wsc.unsubscribe(subID), nil)
+
+	default:
+		wsc.reply(req.ID, nil, &rpcError{Code: -32601, Message: fmt.Sprintf("method not found: %s", req.Method)})
+	}
+}
+
+// registerSub allocates a subscriptionId and a cancellable context for
+// it, tracked so close()/unsubscribe() can tear it down.
+func (wsc *wsConnection) registerSub() (string, context.Context) {
+	subID := uuid.New().String()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	wsc.mu.Lock()
+	wsc.subs[subID] = cancel
+	wsc.mu.Unlock()
+
+	return subID, ctx
+}
+
+// subscribe starts a goroutine that PSubscribes to channel and pushes
+// every message as a logs_subscription/status_subscription notification
+// until the connection closes or the client unsubscribes.
+func (wsc *wsConnection) subscribe(channel, method string) string {
+	subID, ctx := wsc.registerSub()
+
+	go func() {
+		pubsub := wsc.api.subscriber.PSubscribe(ctx, channel)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				wsc.notify(subID, method, json.RawMessage(msg.Payload))
+			}
+		}
+	}()
+
+	return subID
+}
+
+func (wsc *wsConnection) unsubscribe(subID string) bool {
+	wsc.mu.Lock()
+	cancel, ok := wsc.subs[subID]
+	if ok {
+		delete(wsc.subs, subID)
+	}
+	wsc.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+func (wsc *wsConnection) reply(id json.RawMessage, result interface{}, rpcErr *rpcError) {
+	resp := rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Failed to marshal RPC response: %v", err)
+		return
+	}
+	wsc.send(data)
+}
+
+func (wsc *wsConnection) notify(subID, method string, result interface{}) {
+	note := rpcNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  rpcNotificationParams{Subscription: subID, Result: result},
+	}
+	data, err := json.Marshal(note)
+	if err != nil {
+		log.Printf("Failed to marshal RPC notification: %v", err)
+		return
+	}
+	wsc.send(data)
+}
+
+func (wsc *wsConnection) send(data []byte) {
+	defer func() {
+		// The write channel is closed by close() as the connection tears
+		// down; a send racing that close would otherwise panic.
+		recover()
+	}()
+	wsc.writeCh <- wsWriteMsg{messageType: websocket.TextMessage, data: data}
+}
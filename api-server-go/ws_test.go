@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func newTestWSConnection() *wsConnection {
+	return &wsConnection{
+		writeCh: make(chan wsWriteMsg, 8),
+		subs:    make(map[string]context.CancelFunc),
+	}
+}
+
+func readReply(t *testing.T, wsc *wsConnection) rpcResponse {
+	t.Helper()
+	select {
+	case msg := <-wsc.writeCh:
+		var resp rpcResponse
+		if err := json.Unmarshal(msg.data, &resp); err != nil {
+			t.Fatalf("failed to unmarshal reply: %v", err)
+		}
+		return resp
+	case <-time.After(time.Second):
+		t.Fatal("dispatch did not reply in time")
+		return rpcResponse{}
+	}
+}
+
+func TestDispatchPing(t *testing.T) {
+	wsc := newTestWSConnection()
+	wsc.dispatch(rpcRequest{JSONRPC: "2.0", Method: "ping"})
+
+	resp := readReply(t, wsc)
+	if resp.Result != "pong" {
+		t.Errorf("dispatch(ping) result = %v, want %q", resp.Result, "pong")
+	}
+	if resp.Error != nil {
+		t.Errorf("dispatch(ping) error = %v, want nil", resp.Error)
+	}
+}
+
+func TestDispatchUnknownMethod(t *testing.T) {
+	wsc := newTestWSConnection()
+	wsc.dispatch(rpcRequest{JSONRPC: "2.0", Method: "nonexistent"})
+
+	resp := readReply(t, wsc)
+	if resp.Error == nil || resp.Error.Code != -32601 {
+		t.Errorf("dispatch(nonexistent) error = %+v, want code -32601", resp.Error)
+	}
+}
+
+func TestDispatchLogsSubscribeMissingProject(t *testing.T) {
+	wsc := newTestWSConnection()
+	wsc.dispatch(rpcRequest{JSONRPC: "2.0", Method: "logs_subscribe", Params: json.RawMessage(`{}`)})
+
+	resp := readReply(t, wsc)
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Errorf("dispatch(logs_subscribe) with no project error = %+v, want code -32602", resp.Error)
+	}
+}
+
+func TestDispatchStatusSubscribeMissingProject(t *testing.T) {
+	wsc := newTestWSConnection()
+	wsc.dispatch(rpcRequest{JSONRPC: "2.0", Method: "status_subscribe", Params: json.RawMessage(`{}`)})
+
+	resp := readReply(t, wsc)
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Errorf("dispatch(status_subscribe) with no project error = %+v, want code -32602", resp.Error)
+	}
+}
+
+func TestDispatchUnsubscribeUnknownSubscription(t *testing.T) {
+	wsc := newTestWSConnection()
+	wsc.dispatch(rpcRequest{JSONRPC: "2.0", Method: "logs_unsubscribe", Params: json.RawMessage(`"does-not-exist"`)})
+
+	resp := readReply(t, wsc)
+	if resp.Result != false {
+		t.Errorf("dispatch(logs_unsubscribe) for an unknown subscription result = %v, want false", resp.Result)
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/klauspost/compress/zstd"
+)
+
+// lockfiles are checked, in order, for the dependency manager in use.
+var lockfiles = []string{"package-lock.json", "pnpm-lock.yaml", "yarn.lock"}
+
+type cacheConfig struct {
+	bucket    string
+	maxSizeMB int64
+	disabled  bool
+}
+
+func loadCacheConfig() cacheConfig {
+	cfg := cacheConfig{
+		bucket:   os.Getenv("CACHE_BUCKET"),
+		disabled: os.Getenv("CACHE_DISABLED") == "true",
+	}
+	if v := os.Getenv("CACHE_MAX_SIZE_MB"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			cfg.maxSizeMB = n
+		}
+	}
+	if cfg.maxSizeMB == 0 {
+		cfg.maxSizeMB = 500
+	}
+	return cfg
+}
+
+// lockfileHash fingerprints the detected lockfile plus the Node major
+// version, so a cache entry is only reused when both match.
+func lockfileHash(repoDir string) (hash, lockfile string, err error) {
+	for _, name := range lockfiles {
+		path := filepath.Join(repoDir, name)
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			if os.IsNotExist(readErr) {
+				continue
+			}
+			return "", "", readErr
+		}
+
+		h := sha256.New()
+		h.Write(data)
+		h.Write([]byte("\x00node:" + nodeMajorVersion()))
+		return hex.EncodeToString(h.Sum(nil)), name, nil
+	}
+	return "", "", fmt.Errorf("no supported lockfile found")
+}
+
+func nodeMajorVersion() string {
+	v := os.Getenv("MATRIX_NODE_VERSION")
+	if v == "" {
+		out, err := exec.Command("node", "--version").Output()
+		if err != nil {
+			return "unknown"
+		}
+		v = string(out)
+	}
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	major, _, _ := strings.Cut(v, ".")
+	return major
+}
+
+func cacheObjectKey(projectID, hash string) string {
+	return fmt.Sprintf("cache/%s/%s.tar.zst", projectID, hash)
+}
+
+func cacheLatestKey(projectID string) string {
+	return fmt.Sprintf("cache/%s/latest.tar.zst", projectID)
+}
+
+// restoreCache looks up a node_modules cache entry for the repo in
+// outputDir, preferring an exact lockfile-hash match and falling back
+// to the project's last successful cache. It extracts directly into
+// outputDir/node_modules so the subsequent install step becomes a fast
+// no-op / verification rather than a cold install.
+func (bs *BuildServer) restoreCache(outputDir string) {
+	cfg := loadCacheConfig()
+	if cfg.disabled || cfg.bucket == "" {
+		return
+	}
+
+	hash, _, err := lockfileHash(outputDir)
+	if err != nil {
+		bs.publishLog("system", fmt.Sprintf("cache: %v, skipping restore", err))
+		return
+	}
+
+	for _, key := range []string{cacheObjectKey(bs.projectID, hash), cacheLatestKey(bs.projectID)} {
+		if bs.extractCacheObject(cfg.bucket, key, outputDir) {
+			bs.publishLog("system", fmt.Sprintf("cache hit: %s", key))
+			return
+		}
+	}
+
+	bs.publishLog("system", fmt.Sprintf("cache miss: %s", cacheObjectKey(bs.projectID, hash)))
+}
+
+func (bs *BuildServer) extractCacheObject(bucket, key, outputDir string) bool {
+	obj, err := bs.s3Client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return false
+	}
+	defer obj.Body.Close()
+
+	if err := extractTarZst(obj.Body, outputDir); err != nil {
+		log.Printf("Failed to extract cache object %s: %v", key, err)
+		return false
+	}
+	return true
+}
+
+// saveCache tars+zstds the built node_modules and uploads it under both
+// the exact lockfile hash and the project's "latest" pointer, so the
+// next build (even with a different lockfile hash) has something to
+// fall back to. The "latest" upload is skipped if another build of the
+// project holds its lock.
+func (bs *BuildServer) saveCache(outputDir string) {
+	cfg := loadCacheConfig()
+	if cfg.disabled || cfg.bucket == "" {
+		return
+	}
+
+	hash, _, err := lockfileHash(outputDir)
+	if err != nil {
+		return
+	}
+
+	nodeModules := filepath.Join(outputDir, "node_modules")
+	if _, err := os.Stat(nodeModules); err != nil {
+		return
+	}
+
+	archive, err := os.CreateTemp("", "node_modules-cache-*.tar.zst")
+	if err != nil {
+		log.Printf("Failed to create cache archive: %v", err)
+		return
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := tarZstDir(nodeModules, archive); err != nil {
+		log.Printf("Failed to build cache archive: %v", err)
+		return
+	}
+
+	if info, err := archive.Stat(); err == nil && info.Size() > cfg.maxSizeMB*1024*1024 {
+		bs.publishLog("system", fmt.Sprintf("cache: node_modules archive (%dMB) exceeds CACHE_MAX_SIZE_MB, not uploading", info.Size()/1024/1024))
+		return
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		log.Printf("Failed to seek cache archive: %v", err)
+		return
+	}
+
+	key := cacheObjectKey(bs.projectID, hash)
+	if _, err := bs.s3Client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(cfg.bucket),
+		Key:    aws.String(key),
+		Body:   archive,
+	}); err != nil {
+		log.Printf("Failed to upload cache object %s: %v", key, err)
+		return
+	}
+	bs.publishLog("system", fmt.Sprintf("cache: uploaded %s", key))
+
+	bs.updateLatestCache(cfg.bucket, archive)
+}
+
+// updateLatestCache points the project's "latest" cache entry at the
+// archive just uploaded, under a short-lived Redis lock so two
+// concurrent builds of the same project don't interleave writes.
+func (bs *BuildServer) updateLatestCache(bucket string, archive *os.File) {
+	lockKey := fmt.Sprintf("lock:cache-latest:%s", bs.projectID)
+	ok, err := bs.publisher.SetNX(context.Background(), lockKey, "1", 30*time.Second).Result()
+	if err != nil || !ok {
+		return
+	}
+	defer bs.publisher.Del(context.Background(), lockKey)
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		log.Printf("Failed to seek cache archive for latest update: %v", err)
+		return
+	}
+
+	key := cacheLatestKey(bs.projectID)
+	if _, err := bs.s3Client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   archive,
+	}); err != nil {
+		log.Printf("Failed to update latest cache pointer %s: %v", key, err)
+	}
+}
+
+func tarZstDir(dir string, w io.Writer) error {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	defer zw.Close()
+
+	tw := tar.NewWriter(zw)
+	defer tw.Close()
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(filepath.Dir(dir), path)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = relPath
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+}
+
+// safeJoin joins destDir and name the way filepath.Join would, but
+// rejects any entry (absolute path, "../" components) whose cleaned
+// result would land outside destDir. Without this, a crafted cache
+// archive can write anywhere on the build container's filesystem
+// (tar-slip, CWE-22) — and node_modules content here isn't trusted,
+// since it's populated by whatever `npm install` / install scripts the
+// deployed repo ships.
+func safeJoin(destDir, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("tar entry %q escapes destination directory", name)
+	}
+
+	target := filepath.Join(destDir, name)
+	destDir = filepath.Clean(destDir)
+	if target != destDir && !strings.HasPrefix(target, destDir+string(os.PathSeparator)) {
+		return "", fmt.Errorf("tar entry %q escapes destination directory", name)
+	}
+	return target, nil
+}
+
+func extractTarZst(r io.Reader, destDir string) error {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := safeJoin(destDir, header.Name)
+		if err != nil {
+			return fmt.Errorf("cache archive: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tr); err != nil {
+				file.Close()
+				return err
+			}
+			file.Close()
+		}
+	}
+}
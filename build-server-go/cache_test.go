@@ -0,0 +1,74 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestSafeJoinRejectsPathTraversal(t *testing.T) {
+	destDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{"plain file", "node_modules/lodash/index.js", false},
+		{"parent traversal", "../../etc/passwd", true},
+		{"absolute path", "/etc/passwd", true},
+		{"nested traversal", "node_modules/../../escape", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := safeJoin(destDir, tt.entry)
+			if tt.wantErr && err == nil {
+				t.Fatalf("safeJoin(%q) = %q, want error", tt.entry, target)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("safeJoin(%q) returned unexpected error: %v", tt.entry, err)
+			}
+		})
+	}
+}
+
+func TestExtractTarZstRejectsTarSlip(t *testing.T) {
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("failed to create zstd writer: %v", err)
+	}
+	tw := tar.NewWriter(zw)
+
+	const payload = "pwned"
+	if err := tw.WriteHeader(&tar.Header{
+		Name: "../../outside.txt",
+		Mode: 0644,
+		Size: int64(len(payload)),
+	}); err != nil {
+		t.Fatalf("failed to write tar header: %v", err)
+	}
+	if _, err := tw.Write([]byte(payload)); err != nil {
+		t.Fatalf("failed to write tar body: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zstd writer: %v", err)
+	}
+
+	destDir := t.TempDir()
+	if err := extractTarZst(&buf, destDir); err == nil {
+		t.Fatal("extractTarZst accepted a tar entry escaping destDir")
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "..", "..", "outside.txt")); !os.IsNotExist(err) {
+		t.Fatal("extractTarZst wrote a file outside destDir")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const defaultLogStreamMaxLen = 5000
+
+// logStreamMaxLen bounds how many entries each project's log stream is
+// allowed to grow to (approximately — trimming uses MAXLEN ~), via
+// LOG_STREAM_MAXLEN.
+func logStreamMaxLen() int64 {
+	if v := os.Getenv("LOG_STREAM_MAXLEN"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultLogStreamMaxLen
+}
+
+// publishLog appends a log line to the project's Redis stream so that a
+// client connecting after the line was emitted can still replay it,
+// instead of the line being lost the way a plain PUBLISH would lose it.
+// stream is "stdout", "stderr", or "system" for the build server's own
+// narration.
+//
+// If the Redis server doesn't support Streams (or logs:<projectID>
+// already exists as a plain string from a pre-Streams deployment of
+// this project), XAdd fails with WRONGTYPE/unknown command — in that
+// case we fall back to the old PUBLISH path so the message isn't just
+// dropped, matching the degraded mode api-server's subscribeLogs falls
+// back to on the read side.
+func (bs *BuildServer) publishLog(stream, message string) {
+	streamKey := fmt.Sprintf("logs:%s", bs.projectID)
+	ctx := context.Background()
+
+	err := bs.publisher.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey,
+		MaxLen: logStreamMaxLen(),
+		Approx: true,
+		Values: map[string]interface{}{
+			"log":    message,
+			"stream": stream,
+			"ts":     time.Now().UnixNano(),
+		},
+	}).Err()
+	if err == nil {
+		return
+	}
+
+	if !isStreamsUnsupported(err) {
+		log.Printf("Failed to append log: %v", err)
+		return
+	}
+
+	if pubErr := bs.publisher.Publish(ctx, streamKey, message).Err(); pubErr != nil {
+		log.Printf("Failed to publish log (streams unsupported, pubsub fallback also failed): %v", pubErr)
+	}
+}
+
+// isStreamsUnsupported reports whether err indicates the target Redis
+// deployment can't do XADD.
+func isStreamsUnsupported(err error) bool {
+	msg := strings.ToUpper(err.Error())
+	return strings.Contains(msg, "WRONGTYPE") || strings.Contains(msg, "UNKNOWN COMMAND")
+}
+
+// publishStepLog is publishLog with the step name prefixed, so the UI
+// can group a multi-step pipeline's output without parsing it.
+func (bs *BuildServer) publishStepLog(step, stream, message string) {
+	bs.publishLog(stream, fmt.Sprintf("[%s] %s", step, message))
+}
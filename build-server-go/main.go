@@ -66,25 +66,60 @@ func NewBuildServer() (*BuildServer, error) {
 	}, nil
 }
 
-func (bs *BuildServer) publishLog(message string) {
-	logMessage := fmt.Sprintf(`{"log":"%s"}`, message)
-	channel := fmt.Sprintf("logs:%s", bs.projectID)
-	
-	err := bs.publisher.Publish(context.Background(), channel, logMessage).Err()
+func (bs *BuildServer) runBuild() error {
+	log.Println("Executing build script")
+	bs.publishLog("system", "Build Started...")
+	bs.reportStatus("running", "")
+
+	outputDir := filepath.Join(".", "output")
+
+	pipeline, err := LoadPipeline(outputDir)
 	if err != nil {
-		log.Printf("Failed to publish log: %v", err)
+		return fmt.Errorf("failed to load pipeline: %w", err)
 	}
+
+	bs.restoreCache(outputDir)
+
+	env := os.Environ()
+	for _, step := range pipeline.Steps {
+		stepEnv := map[string]string{}
+		for _, kv := range env {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				stepEnv[k] = v
+			}
+		}
+		for k, v := range step.Env {
+			stepEnv[k] = v
+		}
+
+		if !step.shouldRun(stepEnv) {
+			bs.publishStepLog(step.Name, "system", "skipped")
+			continue
+		}
+
+		if err := bs.runStep(outputDir, step, stepEnv); err != nil {
+			return fmt.Errorf("step %q failed: %w", step.Name, err)
+		}
+	}
+
+	log.Println("Build Complete")
+	bs.publishLog("system", "Build Complete")
+
+	bs.saveCache(outputDir)
+
+	// Upload files to S3
+	return bs.uploadFiles(pipeline.OutputDir)
 }
 
-func (bs *BuildServer) runBuild() error {
-	log.Println("Executing build script")
-	bs.publishLog("Build Started...")
+func (bs *BuildServer) runStep(dir string, step Step, stepEnv map[string]string) error {
+	bs.publishStepLog(step.Name, "system", "starting")
 
-	outputDir := filepath.Join(".", "output")
-	
-	// Change to output directory and run npm install && npm run build
-	cmd := exec.Command("sh", "-c", "npm install && npm run build")
-	cmd.Dir = outputDir
+	cmd := exec.Command("sh", "-c", buildStepCommand(step, stepEnv))
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for k, v := range step.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -108,7 +143,7 @@ func (bs *BuildServer) runBuild() error {
 			if n > 0 {
 				output := string(buf[:n])
 				log.Print(output)
-				bs.publishLog(output)
+				bs.publishStepLog(step.Name, "stdout", output)
 			}
 			if err != nil {
 				break
@@ -124,7 +159,7 @@ func (bs *BuildServer) runBuild() error {
 			if n > 0 {
 				output := string(buf[:n])
 				log.Printf("Error: %s", output)
-				bs.publishLog(fmt.Sprintf("error: %s", output))
+				bs.publishStepLog(step.Name, "stderr", output)
 			}
 			if err != nil {
 				break
@@ -133,19 +168,16 @@ func (bs *BuildServer) runBuild() error {
 	}()
 
 	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("build command failed: %w", err)
+		return fmt.Errorf("command failed: %w", err)
 	}
 
-	log.Println("Build Complete")
-	bs.publishLog("Build Complete")
-
-	// Upload files to S3
-	return bs.uploadFiles()
+	bs.publishStepLog(step.Name, "system", "done")
+	return nil
 }
 
-func (bs *BuildServer) uploadFiles() error {
-	distPath := filepath.Join(".", "output", "dist")
-	bs.publishLog("Starting to upload")
+func (bs *BuildServer) uploadFiles(outputDir string) error {
+	distPath := filepath.Join(".", "output", outputDir)
+	bs.publishLog("system", "Starting to upload")
 
 	err := filepath.Walk(distPath, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -163,7 +195,7 @@ func (bs *BuildServer) uploadFiles() error {
 		}
 
 		log.Printf("uploading %s", filePath)
-		bs.publishLog(fmt.Sprintf("uploading %s", relPath))
+		bs.publishLog("system", fmt.Sprintf("uploading %s", relPath))
 
 		// Open file
 		file, err := os.Open(filePath)
@@ -196,7 +228,7 @@ func (bs *BuildServer) uploadFiles() error {
 			return fmt.Errorf("failed to upload file %s: %w", filePath, err)
 		}
 
-		bs.publishLog(fmt.Sprintf("uploaded %s", relPath))
+		bs.publishLog("system", fmt.Sprintf("uploaded %s", relPath))
 		log.Printf("uploaded %s", filePath)
 
 		return nil
@@ -206,8 +238,16 @@ func (bs *BuildServer) uploadFiles() error {
 		return fmt.Errorf("failed to upload files: %w", err)
 	}
 
-	bs.publishLog("Done")
+	bs.publishLog("system", "Done")
 	log.Println("Done...")
+
+	bucket := os.Getenv("S3_BUCKET")
+	if bucket == "" {
+		bucket = "vercel-clone-outputs"
+	}
+	artifactURL := fmt.Sprintf("https://%s.s3.amazonaws.com/__outputs/%s", bucket, bs.projectID)
+	bs.reportStatus("success", artifactURL)
+
 	return nil
 }
 
@@ -218,6 +258,7 @@ func main() {
 	}
 
 	if err := buildServer.runBuild(); err != nil {
+		buildServer.reportStatus("failure", "")
 		log.Fatalf("Build failed: %v", err)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configPaths are checked, in order, for a pipeline definition in the
+// repo being built. The first one found wins.
+var configPaths = []string{
+	"vercel.yaml",
+	"vercel.yml",
+	".vercel/pipeline.yaml",
+	".vercel/pipeline.yml",
+}
+
+// Step is a single unit of work in a Pipeline, run in a shell inside the
+// build container.
+//
+// There is deliberately no `image` field: every step currently runs in
+// the single build container the ECS task already launched, so a
+// per-step image key would parse but silently do nothing. Add it back
+// only once steps can actually be dispatched to their own containers.
+type Step struct {
+	Name     string            `yaml:"name"`
+	Commands []string          `yaml:"commands"`
+	Env      map[string]string `yaml:"env"`
+	When     string            `yaml:"when"`
+}
+
+// Matrix fans a pipeline out across node versions. The API server
+// launches one ECS task per entry, with MATRIX_NODE_VERSION set to it.
+type Matrix struct {
+	Node []string `yaml:"node"`
+}
+
+// Pipeline is the parsed form of vercel.yaml / .vercel/pipeline.yaml.
+type Pipeline struct {
+	OutputDir string  `yaml:"output_dir"`
+	Steps     []Step  `yaml:"steps"`
+	Matrix    *Matrix `yaml:"matrix,omitempty"`
+}
+
+func (p *Pipeline) validate() error {
+	if p.OutputDir == "" {
+		return fmt.Errorf("pipeline: output_dir is required")
+	}
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("pipeline: at least one step is required")
+	}
+	for i, step := range p.Steps {
+		if len(step.Commands) == 0 {
+			return fmt.Errorf("pipeline: step %d (%q) has no commands", i, step.Name)
+		}
+	}
+	return nil
+}
+
+// shouldRun evaluates a step's `when` condition. Conditions are of the
+// form "env.NAME" (true if set to a non-empty value) or
+// "env.NAME == value". An empty
+// condition always runs.
+func (s Step) shouldRun(env map[string]string) bool {
+	if s.When == "" {
+		return true
+	}
+	cond := strings.TrimSpace(s.When)
+	cond = strings.TrimPrefix(cond, "env.")
+
+	if name, value, ok := strings.Cut(cond, "=="); ok {
+		name = strings.TrimSpace(name)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		return env[name] == value
+	}
+	return env[cond] != ""
+}
+
+// nodeVersionPattern restricts MATRIX_NODE_VERSION to the shape nvm
+// expects (e.g. "18", "18.19.0", "lts/hydrogen") before it's
+// interpolated into a shell command.
+var nodeVersionPattern = regexp.MustCompile(`^[a-zA-Z0-9_./-]+$`)
+
+// buildStepCommand joins a step's commands into the shell command line
+// actually executed. When the task was launched for a matrix.node
+// entry, it switches to that Node version via nvm first, so the
+// matrix produces builds that really do run under different Node
+// runtimes instead of just tagging identical tasks with an env var.
+func buildStepCommand(step Step, stepEnv map[string]string) string {
+	command := strings.Join(step.Commands, " && ")
+
+	nodeVersion := stepEnv["MATRIX_NODE_VERSION"]
+	if nodeVersion == "" || !nodeVersionPattern.MatchString(nodeVersion) {
+		return command
+	}
+
+	return fmt.Sprintf(
+		`export NVM_DIR="$HOME/.nvm"; [ -s "$NVM_DIR/nvm.sh" ] && . "$NVM_DIR/nvm.sh"; nvm install %s >/dev/null 2>&1; nvm use %s; %s`,
+		nodeVersion, nodeVersion, command,
+	)
+}
+
+// LoadPipeline looks for a pipeline config in repoDir. If none is found,
+// it detects the project's framework and synthesizes a default pipeline.
+func LoadPipeline(repoDir string) (*Pipeline, error) {
+	for _, rel := range configPaths {
+		path := filepath.Join(repoDir, rel)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		var p Pipeline
+		if err := yaml.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", rel, err)
+		}
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		return &p, nil
+	}
+
+	return defaultPipeline(repoDir)
+}
+
+// defaultPipeline detects the framework in repoDir and synthesizes the
+// equivalent of the old hardcoded "npm install && npm run build".
+func defaultPipeline(repoDir string) (*Pipeline, error) {
+	pkg, err := readPackageJSON(repoDir)
+	if err != nil {
+		return &Pipeline{
+			OutputDir: ".",
+			Steps: []Step{
+				{Name: "copy", Commands: []string{"true"}},
+			},
+		}, nil
+	}
+
+	outputDir := "dist"
+	switch {
+	case pkg.hasDependency("next"):
+		outputDir = ".next"
+	case pkg.hasDependency("vite"):
+		outputDir = "dist"
+	case pkg.hasDependency("react-scripts"):
+		outputDir = "build"
+	}
+
+	return &Pipeline{
+		OutputDir: outputDir,
+		Steps: []Step{
+			{Name: "install", Commands: []string{"npm install"}},
+			{Name: "build", Commands: []string{"npm run build"}},
+		},
+	}, nil
+}
+
+type packageJSON struct {
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+func (p packageJSON) hasDependency(name string) bool {
+	if _, ok := p.Dependencies[name]; ok {
+		return true
+	}
+	_, ok := p.DevDependencies[name]
+	return ok
+}
+
+func readPackageJSON(repoDir string) (packageJSON, error) {
+	data, err := os.ReadFile(filepath.Join(repoDir, "package.json"))
+	if err != nil {
+		return packageJSON{}, err
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return packageJSON{}, err
+	}
+	return pkg, nil
+}
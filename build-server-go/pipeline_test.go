@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestStepShouldRun(t *testing.T) {
+	tests := []struct {
+		name string
+		when string
+		env  map[string]string
+		want bool
+	}{
+		{"empty condition always runs", "", nil, true},
+		{"truthy env var set", "env.DEPLOY", map[string]string{"DEPLOY": "1"}, true},
+		{"truthy env var unset", "env.DEPLOY", map[string]string{}, false},
+		{"equality match", `env.BRANCH == "main"`, map[string]string{"BRANCH": "main"}, true},
+		{"equality mismatch", `env.BRANCH == "main"`, map[string]string{"BRANCH": "dev"}, false},
+		{"equality single-quoted value", "env.BRANCH == 'main'", map[string]string{"BRANCH": "main"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := Step{When: tt.when}
+			if got := step.shouldRun(tt.env); got != tt.want {
+				t.Errorf("shouldRun(%q) = %v, want %v", tt.when, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildStepCommandRejectsUnsafeNodeVersion(t *testing.T) {
+	step := Step{Commands: []string{"npm run build"}}
+
+	got := buildStepCommand(step, map[string]string{"MATRIX_NODE_VERSION": "18; rm -rf /"})
+	if got != "npm run build" {
+		t.Errorf("buildStepCommand with unsafe MATRIX_NODE_VERSION = %q, want unmodified command", got)
+	}
+}
+
+func TestBuildStepCommandSwitchesNodeVersion(t *testing.T) {
+	step := Step{Commands: []string{"npm run build"}}
+
+	got := buildStepCommand(step, map[string]string{"MATRIX_NODE_VERSION": "18.19.0"})
+	if got == "npm run build" {
+		t.Error("buildStepCommand with a valid MATRIX_NODE_VERSION should switch Node runtimes via nvm")
+	}
+}
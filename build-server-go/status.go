@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// reportStatus tells the API server's job registry about a deployment
+// lifecycle transition, via the authenticated callback endpoint. It is
+// a best-effort call: a failure here must never fail the build itself,
+// since the Redis log stream is still the source of truth for the user.
+func (bs *BuildServer) reportStatus(status, artifactURL string) {
+	baseURL := os.Getenv("API_BASE_URL")
+	deploymentID := os.Getenv("DEPLOYMENT_ID")
+	if baseURL == "" || deploymentID == "" {
+		return
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"status":      status,
+		"artifactURL": artifactURL,
+	})
+	if err != nil {
+		log.Printf("Failed to marshal status callback: %v", err)
+		return
+	}
+
+	url := fmt.Sprintf("%s/deployment/%s/status", baseURL, deploymentID)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Failed to build status callback request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv("BUILD_CALLBACK_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Failed to report status %q: %v", status, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Status callback %q rejected with %d", status, resp.StatusCode)
+	}
+}